@@ -0,0 +1,114 @@
+// Package assertion implements the small Venom-style assertion DSL used by
+// the `assert` subcommand: expressions of the form "<var> <operator> <value>"
+// evaluated against a map of resolved variables.
+package assertion
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Evaluate parses expr and evaluates it against vars, returning whether the
+// assertion holds. An error is returned for a malformed expression, an
+// unresolved variable, an unknown operator, or operands that don't match the
+// operator's expected type (e.g. non-numeric ShouldBeGreaterThan operands).
+func Evaluate(expr string, vars map[string]string) (bool, error) {
+	varName, operator, value, err := parse(expr)
+	if err != nil {
+		return false, err
+	}
+
+	actual, ok := vars[varName]
+	if !ok {
+		return false, fmt.Errorf("unknown variable %q", varName)
+	}
+
+	return apply(operator, actual, value)
+}
+
+func parse(expr string) (varName, operator, value string, err error) {
+	fields := strings.SplitN(strings.TrimSpace(expr), " ", 3)
+	if len(fields) < 2 {
+		return "", "", "", fmt.Errorf("malformed assertion %q: expected \"<var> <operator> [value]\"", expr)
+	}
+
+	varName = fields[0]
+	operator = fields[1]
+	if len(fields) == 3 {
+		value = strings.TrimSpace(fields[2])
+	}
+	return varName, operator, value, nil
+}
+
+func apply(operator, actual, value string) (bool, error) {
+	switch operator {
+	case "ShouldEqual":
+		return actual == value, nil
+	case "ShouldNotEqual":
+		return actual != value, nil
+	case "ShouldContainSubstring":
+		return strings.Contains(actual, value), nil
+	case "ShouldMatch":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return false, fmt.Errorf("invalid regexp %q: %w", value, err)
+		}
+		return re.MatchString(actual), nil
+	case "ShouldBeGreaterThan":
+		a, b, err := parseOperands(actual, value)
+		if err != nil {
+			return false, err
+		}
+		return a > b, nil
+	case "ShouldBeLessThan":
+		a, b, err := parseOperands(actual, value)
+		if err != nil {
+			return false, err
+		}
+		return a < b, nil
+	case "ShouldBeEmpty":
+		return actual == "", nil
+	case "ShouldHappenBefore":
+		a, b, err := parseTimestamps(actual, value)
+		if err != nil {
+			return false, err
+		}
+		return a.Before(b), nil
+	case "ShouldBeIn":
+		for _, candidate := range strings.Split(value, ",") {
+			if actual == strings.TrimSpace(candidate) {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown operator %q", operator)
+	}
+}
+
+func parseOperands(actual, value string) (float64, float64, error) {
+	a, err := strconv.ParseFloat(actual, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("actual value %q is not numeric: %w", actual, err)
+	}
+	b, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("comparison value %q is not numeric: %w", value, err)
+	}
+	return a, b, nil
+}
+
+func parseTimestamps(actual, value string) (time.Time, time.Time, error) {
+	a, err := time.Parse(time.RFC3339, actual)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid timestamp %q: %w", actual, err)
+	}
+	b, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid timestamp %q: %w", value, err)
+	}
+	return a, b, nil
+}
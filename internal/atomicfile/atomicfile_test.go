@@ -0,0 +1,123 @@
+package atomicfile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWrite_ReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.yaml")
+
+	if err := Write(path, []byte("v1"), 0644); err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+	if err := Write(path, []byte("v2"), 0644); err != nil {
+		t.Fatalf("second write: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(got) != "v2" {
+		t.Fatalf("got %q, want %q", got, "v2")
+	}
+
+	leftover, _ := filepath.Glob(filepath.Join(dir, "report.yaml.tmp.*"))
+	if len(leftover) != 0 {
+		t.Fatalf("temp files left behind: %v", leftover)
+	}
+}
+
+// TestWrite_StaleTempFileDoesNotCorruptTarget simulates a prior invocation
+// that crashed after creating its temp file but before the rename: a
+// half-written temp file is left next to the target. Readers of the target
+// path must never observe it.
+func TestWrite_StaleTempFileDoesNotCorruptTarget(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.yaml")
+
+	if err := Write(path, []byte("original"), 0644); err != nil {
+		t.Fatalf("seed write: %v", err)
+	}
+
+	stale := path + ".tmp.deadbeef"
+	if err := os.WriteFile(stale, []byte("\xffhalf-writ"), 0644); err != nil {
+		t.Fatalf("seed stale temp file: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(got) != "original" {
+		t.Fatalf("target corrupted by stale temp file: got %q", got)
+	}
+
+	if err := Write(path, []byte("updated"), 0644); err != nil {
+		t.Fatalf("write with stale temp file present: %v", err)
+	}
+
+	got, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read after update: %v", err)
+	}
+	if string(got) != "updated" {
+		t.Fatalf("got %q, want %q", got, "updated")
+	}
+}
+
+// TestWrite_KilledMidWriteNeverCorruptsTarget re-execs the test binary as a
+// helper process that writes a large payload via Write, then kills it while
+// the write is (almost certainly) still in flight. The target must always
+// come back as either its pre-kill content or the fully-written payload,
+// never a partial/corrupt mix of the two.
+func TestWrite_KilledMidWriteNeverCorruptsTarget(t *testing.T) {
+	const payloadSize = 256 << 20 // large enough to not complete instantly on tmpfs
+	payload := bytes.Repeat([]byte("x"), payloadSize)
+
+	if os.Getenv("ATOMICFILE_HELPER_WRITE") == "1" {
+		if err := Write(os.Args[len(os.Args)-1], payload, 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.yaml")
+	if err := Write(path, []byte("original"), 0644); err != nil {
+		t.Fatalf("seed write: %v", err)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestWrite_KilledMidWriteNeverCorruptsTarget$", path)
+	cmd.Env = append(os.Environ(), "ATOMICFILE_HELPER_WRITE=1")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start helper: %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read after kill: %v", err)
+	}
+
+	switch {
+	case string(got) == "original":
+		// Killed before the rename: target untouched, as expected.
+	case bytes.Equal(got, payload):
+		// Write finished (and renamed) before the kill landed; still a
+		// fully valid, non-corrupt result.
+	default:
+		t.Fatalf("target left in a partial/corrupt state after kill mid-write: %d bytes", len(got))
+	}
+}
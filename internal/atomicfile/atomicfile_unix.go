@@ -0,0 +1,15 @@
+//go:build unix
+
+package atomicfile
+
+import "os"
+
+// fsyncDir fsyncs dir so a preceding rename in it is durable across a crash.
+func fsyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package atomicfile
+
+// fsyncDir is a no-op on Windows, which doesn't support opening and syncing
+// a directory handle the way POSIX does.
+func fsyncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,197 @@
+// Package budget implements the optional per-phase duration budgets and
+// run deadline read from a budgets.yaml config: "this phase may not take
+// longer than X" and "the whole run must finish by Y", checked during
+// finalize.
+package budget
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// timestampLayouts are the formats accepted for a deadline, tried in order.
+var timestampLayouts = []string{time.RFC3339, "02-01-2006 15:04"}
+
+// Duration wraps time.Duration so budgets.yaml can use human-readable
+// strings like "30s" or "2m" instead of raw nanoseconds.
+type Duration time.Duration
+
+// MarshalYAML implements yaml.Marshaler.
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Timestamp wraps time.Time so budgets.yaml can use RFC3339 or the
+// "02-01-2006 15:04" form documented for the deadline field.
+type Timestamp time.Time
+
+// MarshalYAML implements yaml.Marshaler.
+func (t Timestamp) MarshalYAML() (interface{}, error) {
+	return time.Time(t).Format(time.RFC3339), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (t *Timestamp) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	for _, layout := range timestampLayouts {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			*t = Timestamp(parsed)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid deadline %q: expected RFC3339 or \"02-01-2006 15:04\"", s)
+}
+
+// Config is the on-disk budgets.yaml: a maximum wall-clock duration per
+// phase, and an optional absolute deadline for the whole run.
+type Config struct {
+	Phases   map[string]Duration `yaml:"phases,omitempty"`
+	Deadline *Timestamp          `yaml:"deadline,omitempty"`
+}
+
+// Load reads a budgets file, returning an empty Config if it doesn't exist.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{Phases: map[string]Duration{}}, nil
+		}
+		return nil, fmt.Errorf("failed to read budgets file %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse budgets file %s: %w", path, err)
+	}
+	if cfg.Phases == nil {
+		cfg.Phases = map[string]Duration{}
+	}
+	return &cfg, nil
+}
+
+// Save writes the config back to path.
+func (c *Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal budgets file: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write budgets file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Set records the maximum duration allowed for phase.
+func (c *Config) Set(phase string, d time.Duration) {
+	if c.Phases == nil {
+		c.Phases = map[string]Duration{}
+	}
+	c.Phases[phase] = Duration(d)
+}
+
+// SetDeadline records the absolute deadline for the whole run.
+func (c *Config) SetDeadline(t time.Time) {
+	ts := Timestamp(t)
+	c.Deadline = &ts
+}
+
+// DeadlineExceeded reports whether now is after the configured deadline.
+// A Config with no deadline is never exceeded.
+func (c *Config) DeadlineExceeded(now time.Time) bool {
+	return c.Deadline != nil && now.After(time.Time(*c.Deadline))
+}
+
+// Step is the subset of a report step needed to evaluate phase budgets.
+type Step struct {
+	Phase     string
+	Timestamp time.Time
+	// Marker is "begin", "end", or "" for a step recorded without explicit
+	// `step --begin`/`--end` framing.
+	Marker string
+}
+
+// Violation records one phase whose actual duration exceeded its budget.
+// StepIndex is the index into the Step slice passed to Evaluate of the
+// specific step the violation was computed from (the "end" step for a
+// begin/end-framed phase, otherwise the step itself), so callers mark only
+// that step as failed instead of every step sharing the phase name.
+type Violation struct {
+	Phase     string
+	StepIndex int
+	Actual    time.Duration
+	Budgeted  time.Duration
+}
+
+// Message renders v as the synthesized failure message recorded on the
+// offending step.
+func (v Violation) Message() string {
+	return fmt.Sprintf("exceeded budget: took %s, budget %s", v.Actual, v.Budgeted)
+}
+
+// Evaluate walks steps in order and returns one Violation per budgeted phase
+// whose actual duration exceeds it. A phase framed with `step --begin`/`--end`
+// uses the gap between those markers; otherwise it uses the gap to the next
+// step in the slice, or to now for the last step in the report (since there
+// is no next step to bound it), as home-ci-reporter doesn't track per-step
+// duration directly.
+func (c *Config) Evaluate(steps []Step, now time.Time) []Violation {
+	begins := make(map[string]time.Time)
+	for _, step := range steps {
+		if step.Marker == "begin" {
+			begins[step.Phase] = step.Timestamp
+		}
+	}
+
+	var violations []Violation
+	evaluated := make(map[string]bool)
+
+	for i, step := range steps {
+		budget, ok := c.Phases[step.Phase]
+		if !ok || evaluated[step.Phase] || step.Marker == "begin" {
+			continue
+		}
+
+		var actual time.Duration
+		switch step.Marker {
+		case "end":
+			begin, ok := begins[step.Phase]
+			if !ok {
+				continue
+			}
+			actual = step.Timestamp.Sub(begin)
+		default:
+			end := now
+			if i+1 < len(steps) {
+				end = steps[i+1].Timestamp
+			}
+			actual = end.Sub(step.Timestamp)
+		}
+
+		evaluated[step.Phase] = true
+		if actual > time.Duration(budget) {
+			violations = append(violations, Violation{Phase: step.Phase, StepIndex: i, Actual: actual, Budgeted: time.Duration(budget)})
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,251 @@
+// Package export converts a test report into industry-standard formats —
+// JUnit XML, OTLP JSON traces and Markdown — for consumption by downstream
+// CI tooling (Jenkins, GitLab, Allure, Grafana Tempo, ...) without each
+// caller having to write a bespoke YAML parser.
+package export
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Step is the subset of a report step needed to render an export format.
+type Step struct {
+	Phase           string
+	Status          string
+	Message         string
+	Timestamp       time.Time
+	AssertionErrors []string
+}
+
+// Report is the subset of a test report needed to render an export format.
+type Report struct {
+	ProjectName   string
+	Runner        string
+	StartTime     time.Time
+	EndTime       time.Time
+	Duration      int
+	TotalSteps    int
+	PassedSteps   int
+	FailedSteps   int
+	OverallStatus string
+	Steps         []Step
+}
+
+// JUnit renders r as a single JUnit <testsuite>, one <testcase> per step.
+func JUnit(r Report) ([]byte, error) {
+	suite := junitTestSuite{
+		Name:     r.ProjectName,
+		Tests:    r.TotalSteps,
+		Failures: r.FailedSteps,
+		Time:     float64(r.Duration),
+	}
+
+	for i, step := range r.Steps {
+		end := r.EndTime
+		if i+1 < len(r.Steps) {
+			end = r.Steps[i+1].Timestamp
+		}
+
+		testCase := junitTestCase{
+			ClassName: r.Runner,
+			Name:      step.Phase,
+			Time:      end.Sub(step.Timestamp).Seconds(),
+		}
+		switch {
+		case step.Status == "failed":
+			testCase.Failure = &junitFailure{
+				Message: step.Message,
+				Type:    step.Status,
+			}
+		case len(step.AssertionErrors) > 0:
+			testCase.Failure = &junitFailure{
+				Message: strings.Join(step.AssertionErrors, "; "),
+				Type:    "assertion",
+			}
+		}
+		if len(step.AssertionErrors) > 0 {
+			testCase.SystemErr = strings.Join(step.AssertionErrors, "\n")
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	out, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JUnit XML: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      float64         `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+}
+
+// OTLPJSON renders r as an OTLP/JSON trace export: one root span for the
+// whole run and one child span per step, with start/end times derived from
+// consecutive step timestamps.
+func OTLPJSON(r Report) ([]byte, error) {
+	traceID, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	rootSpanID, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+
+	spans := []otlpSpan{{
+		TraceID:           traceID,
+		SpanID:            rootSpanID,
+		Name:              r.ProjectName,
+		StartTimeUnixNano: fmt.Sprintf("%d", r.StartTime.UnixNano()),
+		EndTimeUnixNano:   fmt.Sprintf("%d", r.EndTime.UnixNano()),
+		Status:            otlpStatus{Code: statusCode(r.OverallStatus, false)},
+	}}
+
+	for i, step := range r.Steps {
+		spanID, err := randomHex(8)
+		if err != nil {
+			return nil, err
+		}
+
+		end := r.EndTime
+		if i+1 < len(r.Steps) {
+			end = r.Steps[i+1].Timestamp
+		}
+
+		spans = append(spans, otlpSpan{
+			TraceID:           traceID,
+			SpanID:            spanID,
+			ParentSpanID:      rootSpanID,
+			Name:              step.Phase,
+			StartTimeUnixNano: fmt.Sprintf("%d", step.Timestamp.UnixNano()),
+			EndTimeUnixNano:   fmt.Sprintf("%d", end.UnixNano()),
+			Status:            otlpStatus{Code: statusCode(step.Status, len(step.AssertionErrors) > 0)},
+		})
+	}
+
+	doc := otlpTraces{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpAttribute{{
+					Key:   "service.name",
+					Value: otlpAttributeValue{StringValue: r.ProjectName},
+				}},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "home-ci-reporter"},
+				Spans: spans,
+			}},
+		}},
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal OTLP JSON: %w", err)
+	}
+	return out, nil
+}
+
+// statusCode renders status as an OTLP status code, treating a step with any
+// failed assertion as an error even if its own status is "passed".
+func statusCode(status string, hasFailedAssertions bool) string {
+	if status == "failed" || hasFailedAssertions {
+		return "STATUS_CODE_ERROR"
+	}
+	return "STATUS_CODE_OK"
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpAttribute struct {
+	Key   string             `json:"key"`
+	Value otlpAttributeValue `json:"value"`
+}
+
+type otlpAttributeValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Status            otlpStatus `json:"status"`
+}
+
+type otlpStatus struct {
+	Code string `json:"code"`
+}
+
+// Markdown renders r as the same Markdown block used for GITHUB_STEP_SUMMARY
+// and console output, so `export --format markdown` produces a standalone
+// file with identical content.
+func Markdown(r Report) ([]byte, error) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "### 📊 Test Metrics\n")
+	fmt.Fprintf(&b, "- **Overall Status**: %s\n", r.OverallStatus)
+	fmt.Fprintf(&b, "- **Duration**: %ds\n", r.Duration)
+
+	b.WriteString("\n#### 📋 Detailed Steps\n")
+	for _, step := range r.Steps {
+		fmt.Fprintf(&b, "- **%s**: %s _(%s)_\n", step.Phase, step.Status, step.Timestamp.Format(time.RFC3339))
+	}
+
+	return []byte(b.String()), nil
+}
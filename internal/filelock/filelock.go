@@ -0,0 +1,23 @@
+// Package filelock provides a cross-process exclusive lock on a sidecar
+// file, used to serialize the read-modify-write window around report
+// updates so parallel `step` invocations don't race.
+package filelock
+
+import "time"
+
+// Lock is an acquired exclusive lock. Call Release when done with it.
+type Lock struct {
+	file lockFile
+}
+
+// Acquire opens (creating if needed) the lock file at path and blocks until
+// an exclusive lock is obtained or timeout elapses. A zero timeout waits
+// indefinitely.
+func Acquire(path string, timeout time.Duration) (*Lock, error) {
+	return acquire(path, timeout)
+}
+
+// Release releases the lock and closes the underlying file.
+func (l *Lock) Release() error {
+	return l.file.release()
+}
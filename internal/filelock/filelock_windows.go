@@ -0,0 +1,42 @@
+//go:build windows
+
+package filelock
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+type lockFile struct {
+	f *os.File
+}
+
+func acquire(path string, timeout time.Duration) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %w", path, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		overlapped := new(windows.Overlapped)
+		err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, overlapped)
+		if err == nil {
+			return &Lock{file: lockFile{f: f}}, nil
+		}
+		if timeout > 0 && time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("timed out waiting for lock on %s after %s", path, timeout)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (l lockFile) release() error {
+	defer l.f.Close()
+	overlapped := new(windows.Overlapped)
+	return windows.UnlockFileEx(windows.Handle(l.f.Fd()), 0, 1, 0, overlapped)
+}
@@ -0,0 +1,160 @@
+// Package ghactions centralises GitHub Actions workflow-command formatting:
+// escaping, annotation commands (error/warning/notice/group/mask) and the
+// `name<<delimiter` heredoc form used when writing multiline values to
+// GITHUB_OUTPUT, GITHUB_ENV and GITHUB_STEP_SUMMARY.
+package ghactions
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EnabledEnvVar is the environment variable GitHub Actions sets to "true" on
+// every hosted and self-hosted runner.
+const EnabledEnvVar = "GITHUB_ACTIONS"
+
+// Enabled reports whether the process is running inside a GitHub Actions job.
+func Enabled() bool {
+	return os.Getenv(EnabledEnvVar) == "true"
+}
+
+// EscapeData escapes a workflow command's data segment (the part after the
+// final "::"), per the documented command syntax.
+func EscapeData(s string) string {
+	return dataReplacer.Replace(s)
+}
+
+// EscapeProperty escapes a workflow command property value (e.g. the file=,
+// line= entries of an annotation command).
+func EscapeProperty(s string) string {
+	return propertyReplacer.Replace(s)
+}
+
+var dataReplacer = strings.NewReplacer(
+	"%", "%25",
+	"\r", "%0D",
+	"\n", "%0A",
+)
+
+var propertyReplacer = strings.NewReplacer(
+	"%", "%25",
+	"\r", "%0D",
+	"\n", "%0A",
+	":", "%3A",
+	",", "%2C",
+)
+
+// Command formats a workflow command: "::name key=value,...::data".
+// Properties with an empty value are omitted, and keys are emitted in sorted
+// order so the same props always render identically.
+func Command(name string, props map[string]string, data string) string {
+	var b strings.Builder
+	b.WriteString("::")
+	b.WriteString(name)
+
+	keys := make([]string, 0, len(props))
+	for k, v := range props {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		if i == 0 {
+			b.WriteString(" ")
+		} else {
+			b.WriteString(",")
+		}
+		b.WriteString(k)
+		b.WriteString("=")
+		b.WriteString(EscapeProperty(props[k]))
+	}
+
+	b.WriteString("::")
+	b.WriteString(EscapeData(data))
+	return b.String()
+}
+
+// Error formats an "::error ...::message" annotation command.
+func Error(props map[string]string, message string) string {
+	return Command("error", props, message)
+}
+
+// Warning formats a "::warning ...::message" annotation command.
+func Warning(props map[string]string, message string) string {
+	return Command("warning", props, message)
+}
+
+// Notice formats a "::notice ...::message" annotation command.
+func Notice(props map[string]string, message string) string {
+	return Command("notice", props, message)
+}
+
+// Group formats the "::group::title" command that starts a collapsible log
+// group in the Actions UI. Pair with EndGroup.
+func Group(title string) string {
+	return "::group::" + EscapeData(title)
+}
+
+// EndGroup formats the "::endgroup::" command that closes a Group.
+func EndGroup() string {
+	return "::endgroup::"
+}
+
+// Mask formats the "::add-mask::value" command, which tells the Actions
+// runner to redact value from all subsequent log output.
+func Mask(value string) string {
+	return "::add-mask::" + EscapeData(value)
+}
+
+// NewDelimiter returns a random token suitable for the heredoc delimiter in
+// WriteMultiline. It is unique per call so concurrent writers never collide.
+func NewDelimiter() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate delimiter: %w", err)
+	}
+	return "ghadelim_" + hex.EncodeToString(buf), nil
+}
+
+// AppendToFile appends raw content to path, creating it if necessary. It is
+// used for GITHUB_STEP_SUMMARY, which takes freeform Markdown rather than
+// name=value pairs.
+func AppendToFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// WriteMultiline appends a "name<<delimiter\nvalue\ndelimiter" entry to path,
+// the documented heredoc form for setting a multiline value in GITHUB_OUTPUT
+// or GITHUB_ENV without needing to escape newlines in value.
+func WriteMultiline(path, name, value string) error {
+	delim, err := NewDelimiter()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "%s<<%s\n%s\n%s\n", name, delim, value, delim); err != nil {
+		return fmt.Errorf("failed to write %s to %s: %w", name, path, err)
+	}
+	return nil
+}
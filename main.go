@@ -1,23 +1,60 @@
 package main
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
+
+	"github.com/k8s-school/home-ci-reporter/internal/assertion"
+	"github.com/k8s-school/home-ci-reporter/internal/atomicfile"
+	"github.com/k8s-school/home-ci-reporter/internal/budget"
+	"github.com/k8s-school/home-ci-reporter/internal/export"
+	"github.com/k8s-school/home-ci-reporter/internal/filelock"
+	"github.com/k8s-school/home-ci-reporter/internal/ghactions"
 )
 
+// defaultLockTimeout bounds how long a command waits for another
+// home-ci-reporter invocation to finish its read-modify-write of the report
+// before giving up, when no --timeout flag overrides it.
+const defaultLockTimeout = 30 * time.Second
+
 // TestStep represents a single test step
 type TestStep struct {
-	Phase     string    `yaml:"phase"`
-	Status    string    `yaml:"status"`
-	Message   string    `yaml:"message"`
-	Timestamp time.Time `yaml:"timestamp"`
+	Phase       string             `yaml:"phase"`
+	Status      string             `yaml:"status"`
+	Message     string             `yaml:"message"`
+	Timestamp   time.Time          `yaml:"timestamp"`
+	Assertions  []AssertionApplied `yaml:"assertions,omitempty"`
+	Environment *Environment       `yaml:"environment,omitempty"`
+	// Marker is "begin" or "end" when the step was recorded via `step
+	// --begin`/`--end`, framing an explicit duration window for budget
+	// enforcement instead of the gap to the next step.
+	Marker string `yaml:"marker,omitempty"`
+}
+
+// Environment describes the OS/arch/shell a report (or, once merged, an
+// individual step) ran under.
+type Environment struct {
+	OS    string `yaml:"os"`
+	Arch  string `yaml:"arch"`
+	Shell string `yaml:"shell"`
+}
+
+// AssertionApplied records the outcome of evaluating one assertion
+// expression against a step, via the `assert` subcommand.
+type AssertionApplied struct {
+	Assertion string `yaml:"assertion"`
+	IsOK      bool   `yaml:"is_ok"`
+	Error     string `yaml:"error,omitempty"`
 }
 
 // TestReport represents the complete test report
@@ -27,23 +64,34 @@ type TestReport struct {
 		Runner      string    `yaml:"runner"`
 		ProjectName string    `yaml:"project_name,omitempty"`
 	} `yaml:"test_run"`
-	Environment struct {
-		OS    string `yaml:"os"`
-		Arch  string `yaml:"arch"`
-		Shell string `yaml:"shell"`
-	} `yaml:"environment"`
-	Steps   []TestStep `yaml:"steps"`
-	Summary *struct {
-		EndTime       time.Time `yaml:"end_time"`
-		Duration      int       `yaml:"duration_seconds"`
-		TotalSteps    int       `yaml:"total_steps"`
-		PassedSteps   int       `yaml:"passed_steps"`
-		FailedSteps   int       `yaml:"failed_steps"`
-		OverallStatus string    `yaml:"overall_status"`
-		SuccessRate   string    `yaml:"success_rate"`
+	Environment Environment       `yaml:"environment"`
+	Steps       []TestStep        `yaml:"steps"`
+	Vars        map[string]string `yaml:"vars,omitempty"`
+	Shards      []ShardMeta       `yaml:"shards,omitempty"`
+	Summary     *struct {
+		EndTime          time.Time `yaml:"end_time"`
+		Duration         int       `yaml:"duration_seconds"`
+		TotalSteps       int       `yaml:"total_steps"`
+		PassedSteps      int       `yaml:"passed_steps"`
+		FailedSteps      int       `yaml:"failed_steps"`
+		FailedAssertions int       `yaml:"failed_assertions"`
+		OverallStatus    string    `yaml:"overall_status"`
+		SuccessRate      string    `yaml:"success_rate"`
+		BudgetViolations []string  `yaml:"budget_violations,omitempty"`
 	} `yaml:"summary,omitempty"`
 }
 
+// ShardMeta summarises one input report folded into a merged report, so
+// heterogeneous matrix cells remain distinguishable after merge.
+type ShardMeta struct {
+	Name        string `yaml:"name"`
+	Runner      string `yaml:"runner"`
+	OS          string `yaml:"os"`
+	Arch        string `yaml:"arch"`
+	PassedSteps int    `yaml:"passed_steps"`
+	FailedSteps int    `yaml:"failed_steps"`
+}
+
 // GitHubPayload represents the GitHub Actions client payload structure
 type GitHubPayload struct {
 	Success      bool                       `json:"success"`
@@ -61,6 +109,48 @@ type ArtifactContent struct {
 
 var reportFile string
 
+// Flags controlling the GitHub Actions annotation emitted by addStep.
+var (
+	stepAnnotationFile string
+	stepLine           int
+	stepCol            int
+	stepTitle          string
+	stepMask           []string
+	stepCapture        []string
+	stepEnvExports     []string
+	stepTimeout        time.Duration
+	stepBegin          bool
+	stepEnd            bool
+)
+
+// finalizeTimeout bounds how long finalize waits for the report file lock.
+// finalizeBudgets, if set, points at a budgets.yaml enforced during finalize.
+var (
+	finalizeTimeout time.Duration
+	finalizeBudgets string
+)
+
+// budgetsPath is shared by the budget set/deadline subcommands.
+var budgetsPath string
+
+// Flags for the assert subcommand.
+var (
+	assertPhase string
+	assertVars  []string
+)
+
+// Flags for the export subcommand.
+var (
+	exportFormat string
+	exportOutput string
+)
+
+// Flags for the merge subcommand.
+var (
+	mergeOutput     string
+	mergeDedupPhase bool
+)
+
 func main() {
 	var rootCmd = &cobra.Command{
 		Use:   "home-ci-reporter",
@@ -88,6 +178,14 @@ func main() {
 		RunE:  finalizeReport,
 	}
 
+	var assertCmd = &cobra.Command{
+		Use:   "assert <expr>",
+		Short: "Evaluate an assertion expression against a step and record the result",
+		Long:  "Evaluates an expression of the form \"<var> <operator> <value>\" (e.g. ShouldEqual, ShouldContainSubstring, ShouldMatch) against --var flags and previously captured step outputs, then appends the result to the --phase step.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  assertStep,
+	}
+
 	var parseCmd = &cobra.Command{
 		Use:   "parse <report-file>",
 		Short: "Parse and display test report with GitHub Actions formatting",
@@ -109,13 +207,79 @@ func main() {
 		RunE:  generateSummary,
 	}
 
+	var exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Convert a test report to a downstream CI format (junit, otlp-json, markdown)",
+		RunE:  exportReport,
+	}
+
+	var mergeCmd = &cobra.Command{
+		Use:   "merge <report1.yaml> <report2.yaml> ...",
+		Short: "Merge matrix/sharded job reports into one combined report",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  mergeReports,
+	}
+
+	var budgetCmd = &cobra.Command{
+		Use:   "budget",
+		Short: "Manage the phase duration budgets and run deadline read by finalize --budgets",
+	}
+
+	var budgetSetCmd = &cobra.Command{
+		Use:   "set <phase> <duration>",
+		Short: "Set the maximum duration allowed for a phase (e.g. 30s, 2m)",
+		Args:  cobra.ExactArgs(2),
+		RunE:  budgetSet,
+	}
+
+	var budgetDeadlineCmd = &cobra.Command{
+		Use:   "deadline <timestamp>",
+		Short: "Set the absolute deadline for the whole run (RFC3339 or \"02-01-2006 15:04\")",
+		Args:  cobra.ExactArgs(1),
+		RunE:  budgetSetDeadline,
+	}
+
 	stepCmd.Flags().StringVarP(&reportFile, "file", "f", "", "Report file path (required)")
 	stepCmd.MarkFlagRequired("file")
+	// Named "annotation-file" rather than "--file" (the request's suggested
+	// name) because "-f/--file" is already bound to the report path above.
+	stepCmd.Flags().StringVar(&stepAnnotationFile, "annotation-file", "", "Source file to attach the GitHub Actions annotation to")
+	stepCmd.Flags().IntVar(&stepLine, "line", 0, "Source line number for the GitHub Actions annotation")
+	stepCmd.Flags().IntVar(&stepCol, "col", 0, "Source column number for the GitHub Actions annotation")
+	stepCmd.Flags().StringVar(&stepTitle, "title", "", "Annotation title (defaults to the phase name)")
+	stepCmd.Flags().StringArrayVar(&stepMask, "mask", nil, "Value to redact from logs via ::add-mask:: (repeatable)")
+	stepCmd.Flags().StringArrayVar(&stepCapture, "capture", nil, "Capture a variable as name=<file|stdout> into the report's vars map (repeatable)")
+	stepCmd.Flags().StringArrayVar(&stepEnvExports, "export-env", nil, "Export a variable as name=value to GITHUB_ENV for later steps (repeatable)")
+	stepCmd.Flags().DurationVar(&stepTimeout, "timeout", defaultLockTimeout, "How long to wait for the report file lock")
+	stepCmd.Flags().BoolVar(&stepBegin, "begin", false, "Mark this step as the start of a phase, for budget evaluation")
+	stepCmd.Flags().BoolVar(&stepEnd, "end", false, "Mark this step as the end of a phase, for budget evaluation")
 
 	finalizeCmd.Flags().StringVarP(&reportFile, "file", "f", "", "Report file path (required)")
 	finalizeCmd.MarkFlagRequired("file")
+	finalizeCmd.Flags().DurationVar(&finalizeTimeout, "timeout", defaultLockTimeout, "How long to wait for the report file lock")
+	finalizeCmd.Flags().StringVar(&finalizeBudgets, "budgets", "", "Path to a budgets.yaml of per-phase duration budgets and an optional deadline")
+
+	assertCmd.Flags().StringVarP(&reportFile, "file", "f", "", "Report file path (required)")
+	assertCmd.MarkFlagRequired("file")
+	assertCmd.Flags().StringVar(&assertPhase, "phase", "", "Phase of the step to attach the assertion result to (required)")
+	assertCmd.MarkFlagRequired("phase")
+	assertCmd.Flags().StringArrayVar(&assertVars, "var", nil, "Variable available to the assertion as key=value (repeatable)")
+
+	exportCmd.Flags().StringVarP(&reportFile, "file", "f", "", "Report file path (required)")
+	exportCmd.MarkFlagRequired("file")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "", "Output format: junit, otlp-json or markdown (required)")
+	exportCmd.MarkFlagRequired("format")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Output file path (required)")
+	exportCmd.MarkFlagRequired("output")
 
-	rootCmd.AddCommand(initCmd, stepCmd, finalizeCmd, parseCmd, extractCmd, summaryCmd)
+	mergeCmd.Flags().StringVarP(&mergeOutput, "output", "o", "", "Combined report output path (required)")
+	mergeCmd.MarkFlagRequired("output")
+	mergeCmd.Flags().BoolVar(&mergeDedupPhase, "dedup-phase", false, "Collapse repeated phases into a single step with the worst merged status")
+
+	budgetCmd.PersistentFlags().StringVar(&budgetsPath, "budgets", "budgets.yaml", "Path to the budgets file to update")
+	budgetCmd.AddCommand(budgetSetCmd, budgetDeadlineCmd)
+
+	rootCmd.AddCommand(initCmd, stepCmd, finalizeCmd, assertCmd, parseCmd, extractCmd, summaryCmd, exportCmd, mergeCmd, budgetCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -160,6 +324,16 @@ func addStep(cmd *cobra.Command, args []string) error {
 	status := args[1]
 	message := args[2]
 
+	if stepBegin && stepEnd {
+		return fmt.Errorf("--begin and --end are mutually exclusive")
+	}
+
+	lock, err := filelock.Acquire(lockPath(reportFile), stepTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
 	report, err := readReport(reportFile)
 	if err != nil {
 		return err
@@ -171,13 +345,247 @@ func addStep(cmd *cobra.Command, args []string) error {
 		Message:   message,
 		Timestamp: time.Now().UTC(),
 	}
+	switch {
+	case stepBegin:
+		step.Marker = "begin"
+	case stepEnd:
+		step.Marker = "end"
+	}
 
 	report.Steps = append(report.Steps, step)
 
-	return writeReport(reportFile, *report)
+	if err := captureVars(report, message); err != nil {
+		return err
+	}
+
+	if err := writeReport(reportFile, *report); err != nil {
+		return err
+	}
+
+	emitStepAnnotation(step)
+
+	if outputFile := os.Getenv("GITHUB_OUTPUT"); outputFile != "" {
+		if err := ghactions.WriteMultiline(outputFile, sanitizeOutputName(phase)+"_message", message); err != nil {
+			return err
+		}
+	}
+
+	if envFile := os.Getenv("GITHUB_ENV"); envFile != "" {
+		for _, export := range stepEnvExports {
+			name, value, ok := strings.Cut(export, "=")
+			if !ok {
+				return fmt.Errorf("invalid --export-env %q: expected name=value", export)
+			}
+			if err := ghactions.WriteMultiline(envFile, name, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// emitStepAnnotation writes the workflow-command annotations for a step to
+// stdout: a masked-value list, a collapsible ::group:: around the step's
+// phase, and an ::error::/::warning::/::notice:: sized to its status. It is
+// a no-op outside GitHub Actions.
+func emitStepAnnotation(step TestStep) {
+	if !ghactions.Enabled() {
+		return
+	}
+
+	for _, secret := range stepMask {
+		fmt.Println(ghactions.Mask(secret))
+	}
+
+	fmt.Println(ghactions.Group(step.Phase))
+	defer fmt.Println(ghactions.EndGroup())
+
+	title := stepTitle
+	if title == "" {
+		title = step.Phase
+	}
+	props := map[string]string{"title": title}
+	if stepAnnotationFile != "" {
+		props["file"] = stepAnnotationFile
+	}
+	if stepLine > 0 {
+		props["line"] = fmt.Sprintf("%d", stepLine)
+	}
+	if stepCol > 0 {
+		props["col"] = fmt.Sprintf("%d", stepCol)
+	}
+
+	switch step.Status {
+	case "failed":
+		fmt.Println(ghactions.Error(props, step.Message))
+	case "passed":
+		fmt.Println(step.Message)
+	case "warning":
+		fmt.Println(ghactions.Warning(props, step.Message))
+	default:
+		fmt.Println(ghactions.Notice(props, step.Message))
+	}
+}
+
+// lockPath returns the sidecar lock file path guarding reportPath's
+// read-modify-write window.
+func lockPath(reportPath string) string {
+	return reportPath + ".lock"
+}
+
+// sanitizeOutputName turns a phase name into a valid GITHUB_OUTPUT variable
+// name by replacing anything other than letters, digits and underscores.
+func sanitizeOutputName(phase string) string {
+	return outputNameReplacer.Replace(phase)
+}
+
+var outputNameReplacer = strings.NewReplacer(
+	" ", "_",
+	"-", "_",
+	".", "_",
+	"/", "_",
+)
+
+// captureVars applies the --capture name=<file|stdout> flags to report.Vars.
+// "stdout" captures the step's own message; any other source is read as a
+// file path.
+func captureVars(report *TestReport, message string) error {
+	if len(stepCapture) == 0 {
+		return nil
+	}
+
+	if report.Vars == nil {
+		report.Vars = make(map[string]string)
+	}
+
+	for _, capture := range stepCapture {
+		name, source, ok := strings.Cut(capture, "=")
+		if !ok {
+			return fmt.Errorf("invalid --capture %q: expected name=<file|stdout>", capture)
+		}
+
+		if source == "stdout" {
+			report.Vars[name] = message
+			continue
+		}
+
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("failed to capture %s from %s: %w", name, source, err)
+		}
+		report.Vars[name] = strings.TrimRight(string(data), "\n")
+	}
+
+	return nil
+}
+
+// assertStep evaluates the expr argument against a context map built from
+// report.Vars overlaid with --var flags, then appends the resulting
+// AssertionApplied to the last step matching --phase.
+func assertStep(cmd *cobra.Command, args []string) error {
+	expr := args[0]
+
+	lock, err := filelock.Acquire(lockPath(reportFile), defaultLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	report, err := readReport(reportFile)
+	if err != nil {
+		return err
+	}
+
+	stepIndex := -1
+	for i, step := range report.Steps {
+		if step.Phase == assertPhase {
+			stepIndex = i
+		}
+	}
+	if stepIndex == -1 {
+		return fmt.Errorf("no step found for phase %q", assertPhase)
+	}
+
+	vars := make(map[string]string, len(report.Vars)+len(assertVars))
+	for k, v := range report.Vars {
+		vars[k] = v
+	}
+	for _, kv := range assertVars {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return fmt.Errorf("invalid --var %q: expected key=value", kv)
+		}
+		vars[k] = v
+	}
+
+	applied := AssertionApplied{Assertion: expr}
+	isOK, err := assertion.Evaluate(expr, vars)
+	if err != nil {
+		applied.Error = err.Error()
+	} else {
+		applied.IsOK = isOK
+	}
+
+	report.Steps[stepIndex].Assertions = append(report.Steps[stepIndex].Assertions, applied)
+
+	if err := writeReport(reportFile, *report); err != nil {
+		return err
+	}
+
+	if applied.Error != "" {
+		return fmt.Errorf("assertion error: %s", applied.Error)
+	}
+	if !applied.IsOK {
+		return fmt.Errorf("assertion failed: %s", expr)
+	}
+	return nil
+}
+
+// budgetSet sets the maximum duration allowed for a phase in --budgets.
+func budgetSet(cmd *cobra.Command, args []string) error {
+	phase := args[0]
+	d, err := time.ParseDuration(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", args[1], err)
+	}
+
+	cfg, err := budget.Load(budgetsPath)
+	if err != nil {
+		return err
+	}
+	cfg.Set(phase, d)
+	return cfg.Save(budgetsPath)
+}
+
+// budgetSetDeadline sets the absolute deadline for the whole run in --budgets.
+func budgetSetDeadline(cmd *cobra.Command, args []string) error {
+	cfg, err := budget.Load(budgetsPath)
+	if err != nil {
+		return err
+	}
+
+	var deadline budget.Timestamp
+	if err := deadline.UnmarshalYAML(yamlScalarNode(args[0])); err != nil {
+		return err
+	}
+	cfg.SetDeadline(time.Time(deadline))
+	return cfg.Save(budgetsPath)
+}
+
+// yamlScalarNode wraps s as the *yaml.Node Duration/Timestamp's UnmarshalYAML
+// expects, so the CLI can reuse that parsing for a bare string argument.
+func yamlScalarNode(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: s}
 }
 
 func finalizeReport(cmd *cobra.Command, args []string) error {
+	lock, err := filelock.Acquire(lockPath(reportFile), finalizeTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
 	report, err := readReport(reportFile)
 	if err != nil {
 		return err
@@ -189,6 +597,7 @@ func finalizeReport(cmd *cobra.Command, args []string) error {
 	totalSteps := len(report.Steps)
 	passedSteps := 0
 	failedSteps := 0
+	failedAssertions := 0
 
 	for _, step := range report.Steps {
 		switch step.Status {
@@ -197,10 +606,49 @@ func finalizeReport(cmd *cobra.Command, args []string) error {
 		case "failed":
 			failedSteps++
 		}
+		for _, applied := range step.Assertions {
+			if !applied.IsOK {
+				failedAssertions++
+			}
+		}
+	}
+
+	var violationMessages []string
+	deadlineExceeded := false
+	if finalizeBudgets != "" {
+		cfg, err := budget.Load(finalizeBudgets)
+		if err != nil {
+			return err
+		}
+
+		budgetSteps := make([]budget.Step, len(report.Steps))
+		for i, step := range report.Steps {
+			budgetSteps[i] = budget.Step{Phase: step.Phase, Timestamp: step.Timestamp, Marker: step.Marker}
+		}
+
+		for _, violation := range cfg.Evaluate(budgetSteps, endTime) {
+			violationMessages = append(violationMessages, violation.Message())
+			if report.Steps[violation.StepIndex].Status != "failed" {
+				report.Steps[violation.StepIndex].Status = "failed"
+				report.Steps[violation.StepIndex].Message = violation.Message()
+			}
+		}
+
+		deadlineExceeded = cfg.DeadlineExceeded(endTime)
+
+		passedSteps, failedSteps = 0, 0
+		for _, step := range report.Steps {
+			switch step.Status {
+			case "passed":
+				passedSteps++
+			case "failed":
+				failedSteps++
+			}
+		}
 	}
 
 	overallStatus := "passed"
-	if failedSteps > 0 {
+	if failedSteps > 0 || failedAssertions > 0 || deadlineExceeded {
 		overallStatus = "failed"
 	}
 
@@ -210,24 +658,287 @@ func finalizeReport(cmd *cobra.Command, args []string) error {
 	}
 
 	report.Summary = &struct {
-		EndTime       time.Time `yaml:"end_time"`
-		Duration      int       `yaml:"duration_seconds"`
-		TotalSteps    int       `yaml:"total_steps"`
-		PassedSteps   int       `yaml:"passed_steps"`
-		FailedSteps   int       `yaml:"failed_steps"`
-		OverallStatus string    `yaml:"overall_status"`
-		SuccessRate   string    `yaml:"success_rate"`
+		EndTime          time.Time `yaml:"end_time"`
+		Duration         int       `yaml:"duration_seconds"`
+		TotalSteps       int       `yaml:"total_steps"`
+		PassedSteps      int       `yaml:"passed_steps"`
+		FailedSteps      int       `yaml:"failed_steps"`
+		FailedAssertions int       `yaml:"failed_assertions"`
+		OverallStatus    string    `yaml:"overall_status"`
+		SuccessRate      string    `yaml:"success_rate"`
+		BudgetViolations []string  `yaml:"budget_violations,omitempty"`
+	}{
+		EndTime:          endTime,
+		Duration:         duration,
+		TotalSteps:       totalSteps,
+		PassedSteps:      passedSteps,
+		FailedSteps:      failedSteps,
+		FailedAssertions: failedAssertions,
+		OverallStatus:    overallStatus,
+		SuccessRate:      successRate,
+		BudgetViolations: violationMessages,
+	}
+
+	if err := writeReport(reportFile, *report); err != nil {
+		return err
+	}
+
+	if ghactions.Enabled() {
+		fmt.Println(ghactions.Group("summary"))
+		message := fmt.Sprintf("%d/%d steps passed (%s) in %ds", passedSteps, totalSteps, successRate, duration)
+		if overallStatus == "failed" {
+			fmt.Println(ghactions.Error(map[string]string{"title": "overall_status"}, message))
+		} else {
+			fmt.Println(ghactions.Notice(map[string]string{"title": "overall_status"}, message))
+		}
+		fmt.Println(ghactions.EndGroup())
+	}
+
+	return nil
+}
+
+// exportReport converts the report at reportFile into --format and writes it
+// to --output.
+func exportReport(cmd *cobra.Command, args []string) error {
+	report, err := readReport(reportFile)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	switch exportFormat {
+	case "junit":
+		data, err = export.JUnit(toExportReport(*report))
+	case "otlp-json":
+		data, err = export.OTLPJSON(toExportReport(*report))
+	case "markdown":
+		data, err = export.Markdown(toExportReport(*report))
+	default:
+		return fmt.Errorf("unknown format %q: expected junit, otlp-json or markdown", exportFormat)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export report: %w", err)
+	}
+
+	if err := os.WriteFile(exportOutput, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", exportOutput, err)
+	}
+
+	return nil
+}
+
+// toExportReport adapts the YAML-oriented TestReport into the plain struct
+// the export package renders, so export stays decoupled from the report's
+// on-disk schema.
+func toExportReport(report TestReport) export.Report {
+	r := export.Report{
+		ProjectName: report.TestRun.ProjectName,
+		Runner:      report.TestRun.Runner,
+		StartTime:   report.TestRun.StartTime,
+	}
+
+	for _, step := range report.Steps {
+		exportStep := export.Step{
+			Phase:     step.Phase,
+			Status:    step.Status,
+			Message:   step.Message,
+			Timestamp: step.Timestamp,
+		}
+		for _, applied := range step.Assertions {
+			if !applied.IsOK {
+				msg := applied.Error
+				if msg == "" {
+					msg = "assertion failed: " + applied.Assertion
+				}
+				exportStep.AssertionErrors = append(exportStep.AssertionErrors, msg)
+			}
+		}
+		r.Steps = append(r.Steps, exportStep)
+
+		switch step.Status {
+		case "passed":
+			r.PassedSteps++
+		case "failed":
+			r.FailedSteps++
+		}
+	}
+	r.TotalSteps = len(report.Steps)
+
+	if report.Summary != nil {
+		r.EndTime = report.Summary.EndTime
+		r.Duration = report.Summary.Duration
+		r.OverallStatus = report.Summary.OverallStatus
+	} else {
+		r.OverallStatus = "passed"
+		if r.FailedSteps > 0 {
+			r.OverallStatus = "failed"
+		}
+	}
+
+	return r
+}
+
+// mergeReports reads each report in args and writes their combination to
+// --output.
+func mergeReports(cmd *cobra.Command, args []string) error {
+	reports := make([]TestReport, 0, len(args))
+	for _, path := range args {
+		report, err := readReport(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		reports = append(reports, *report)
+	}
+
+	return writeReport(mergeOutput, mergeTestReports(reports, mergeDedupPhase))
+}
+
+// mergeTestReports combines reports per the merge subcommand's semantics:
+// TestRun.StartTime is the min across inputs, Summary.EndTime the max, steps
+// are concatenated in timestamp order with each step's source Environment
+// attached, and one ShardMeta records each input's own pass/fail tally.
+func mergeTestReports(reports []TestReport, dedupPhase bool) TestReport {
+	var combined TestReport
+	combined.TestRun.Runner = "merged"
+
+	for i, report := range reports {
+		if i == 0 || report.TestRun.StartTime.Before(combined.TestRun.StartTime) {
+			combined.TestRun.StartTime = report.TestRun.StartTime
+		}
+		if combined.TestRun.ProjectName == "" {
+			combined.TestRun.ProjectName = report.TestRun.ProjectName
+		}
+
+		env := report.Environment
+		passedSteps, failedSteps := 0, 0
+		for _, step := range report.Steps {
+			step.Environment = &env
+			combined.Steps = append(combined.Steps, step)
+			switch step.Status {
+			case "passed":
+				passedSteps++
+			case "failed":
+				failedSteps++
+			}
+		}
+
+		shardName := report.TestRun.ProjectName
+		if shardName == "" {
+			shardName = fmt.Sprintf("shard-%d", i)
+		}
+		combined.Shards = append(combined.Shards, ShardMeta{
+			Name:        shardName,
+			Runner:      report.TestRun.Runner,
+			OS:          report.Environment.OS,
+			Arch:        report.Environment.Arch,
+			PassedSteps: passedSteps,
+			FailedSteps: failedSteps,
+		})
+	}
+
+	sort.Slice(combined.Steps, func(i, j int) bool {
+		return combined.Steps[i].Timestamp.Before(combined.Steps[j].Timestamp)
+	})
+
+	if dedupPhase {
+		combined.Steps = dedupStepsByPhase(combined.Steps)
+	}
+
+	endTime := combined.TestRun.StartTime
+	for _, report := range reports {
+		if report.Summary != nil && report.Summary.EndTime.After(endTime) {
+			endTime = report.Summary.EndTime
+		}
+	}
+	if len(combined.Steps) > 0 {
+		if last := combined.Steps[len(combined.Steps)-1].Timestamp; last.After(endTime) {
+			endTime = last
+		}
+	}
+
+	totalSteps := len(combined.Steps)
+	passedSteps, failedSteps, failedAssertions := 0, 0, 0
+	for _, step := range combined.Steps {
+		switch step.Status {
+		case "passed":
+			passedSteps++
+		case "failed":
+			failedSteps++
+		}
+		for _, applied := range step.Assertions {
+			if !applied.IsOK {
+				failedAssertions++
+			}
+		}
+	}
+
+	overallStatus := "passed"
+	if failedSteps > 0 || failedAssertions > 0 {
+		overallStatus = "failed"
+	}
+
+	successRate := "0%"
+	if totalSteps > 0 {
+		successRate = fmt.Sprintf("%.0f%%", float64(passedSteps)/float64(totalSteps)*100)
+	}
+
+	combined.Summary = &struct {
+		EndTime          time.Time `yaml:"end_time"`
+		Duration         int       `yaml:"duration_seconds"`
+		TotalSteps       int       `yaml:"total_steps"`
+		PassedSteps      int       `yaml:"passed_steps"`
+		FailedSteps      int       `yaml:"failed_steps"`
+		FailedAssertions int       `yaml:"failed_assertions"`
+		OverallStatus    string    `yaml:"overall_status"`
+		SuccessRate      string    `yaml:"success_rate"`
+		BudgetViolations []string  `yaml:"budget_violations,omitempty"`
 	}{
-		EndTime:       endTime,
-		Duration:      duration,
-		TotalSteps:    totalSteps,
-		PassedSteps:   passedSteps,
-		FailedSteps:   failedSteps,
-		OverallStatus: overallStatus,
-		SuccessRate:   successRate,
+		EndTime:          endTime,
+		Duration:         int(endTime.Sub(combined.TestRun.StartTime).Seconds()),
+		TotalSteps:       totalSteps,
+		PassedSteps:      passedSteps,
+		FailedSteps:      failedSteps,
+		FailedAssertions: failedAssertions,
+		OverallStatus:    overallStatus,
+		SuccessRate:      successRate,
+	}
+
+	return combined
+}
+
+// dedupStepsByPhase collapses steps sharing a phase into a single step,
+// keeping the latest timestamp and the worst status of the merged set
+// (failed > warning > passed), and concatenating their assertions.
+func dedupStepsByPhase(steps []TestStep) []TestStep {
+	statusRank := map[string]int{"passed": 0, "warning": 1, "failed": 2}
+
+	order := make([]string, 0, len(steps))
+	byPhase := make(map[string]TestStep, len(steps))
+
+	for _, step := range steps {
+		existing, ok := byPhase[step.Phase]
+		if !ok {
+			order = append(order, step.Phase)
+			byPhase[step.Phase] = step
+			continue
+		}
+
+		if statusRank[step.Status] > statusRank[existing.Status] {
+			existing.Status = step.Status
+			existing.Message = step.Message
+		}
+		if step.Timestamp.After(existing.Timestamp) {
+			existing.Timestamp = step.Timestamp
+		}
+		existing.Assertions = append(existing.Assertions, step.Assertions...)
+		byPhase[step.Phase] = existing
 	}
 
-	return writeReport(reportFile, *report)
+	deduped := make([]TestStep, 0, len(order))
+	for _, phase := range order {
+		deduped = append(deduped, byPhase[phase])
+	}
+	return deduped
 }
 
 func readReport(path string) (*TestReport, error) {
@@ -245,28 +956,21 @@ func readReport(path string) (*TestReport, error) {
 }
 
 func writeReport(path string, report TestReport) error {
-	// Write with proper YAML formatting and header comment
-	file, err := os.Create(path)
-	if err != nil {
-		return fmt.Errorf("failed to create report file: %w", err)
-	}
-	defer file.Close()
+	var buf bytes.Buffer
 
-	// Write header comment
-	if _, err := file.WriteString("# E2E Test Report\n"); err != nil {
-		return fmt.Errorf("failed to write header: %w", err)
-	}
+	buf.WriteString("# E2E Test Report\n")
 
-	// Marshal and write YAML
-	encoder := yaml.NewEncoder(file)
+	encoder := yaml.NewEncoder(&buf)
 	encoder.SetIndent(2)
-	defer encoder.Close()
-
 	if err := encoder.Encode(report); err != nil {
+		encoder.Close()
 		return fmt.Errorf("failed to encode YAML: %w", err)
 	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to flush YAML encoder: %w", err)
+	}
 
-	return nil
+	return atomicfile.Write(path, buf.Bytes(), 0644)
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
@@ -316,49 +1020,25 @@ func outputReportToConsole(report TestReport) error {
 }
 
 func appendReportToGitHubSummary(report TestReport, summaryPath string) error {
-	file, err := os.OpenFile(summaryPath, os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open GitHub summary file: %w", err)
-	}
-	defer file.Close()
+	var b strings.Builder
 
-	// Write test metrics section
-	if _, err := file.WriteString("### 📊 Test Metrics\n"); err != nil {
-		return fmt.Errorf("failed to write to summary: %w", err)
-	}
+	b.WriteString("### 📊 Test Metrics\n")
 
 	if report.Summary != nil {
 		s := report.Summary
-		if _, err := fmt.Fprintf(file, "- **Overall Status**: %s\n", s.OverallStatus); err != nil {
-			return fmt.Errorf("failed to write overall status: %w", err)
-		}
-		if _, err := fmt.Fprintf(file, "- **Success Rate**: %s\n", s.SuccessRate); err != nil {
-			return fmt.Errorf("failed to write success rate: %w", err)
-		}
-		if _, err := fmt.Fprintf(file, "- **Duration**: %ds\n", s.Duration); err != nil {
-			return fmt.Errorf("failed to write duration: %w", err)
-		}
-
-		// Write detailed steps
-		if _, err := file.WriteString("\n#### 📋 Detailed Steps\n"); err != nil {
-			return fmt.Errorf("failed to write steps header: %w", err)
-		}
+		fmt.Fprintf(&b, "- **Overall Status**: %s\n", s.OverallStatus)
+		fmt.Fprintf(&b, "- **Success Rate**: %s\n", s.SuccessRate)
+		fmt.Fprintf(&b, "- **Duration**: %ds\n", s.Duration)
 
+		b.WriteString("\n#### 📋 Detailed Steps\n")
 		for _, step := range report.Steps {
-			if _, err := fmt.Fprintf(file, "- **%s**: %s _(%s)_\n",
-				step.Phase,
-				step.Status,
-				step.Timestamp.Format(time.RFC3339)); err != nil {
-				return fmt.Errorf("failed to write step: %w", err)
-			}
+			fmt.Fprintf(&b, "- **%s**: %s _(%s)_\n", step.Phase, step.Status, step.Timestamp.Format(time.RFC3339))
 		}
 	} else {
-		if _, err := file.WriteString("⚠️ No summary data available\n"); err != nil {
-			return fmt.Errorf("failed to write no summary message: %w", err)
-		}
+		b.WriteString("⚠️ No summary data available\n")
 	}
 
-	return nil
+	return ghactions.AppendToFile(summaryPath, b.String())
 }
 
 func debugPrint(report TestReport) {
@@ -454,14 +1134,8 @@ func generateSummary(cmd *cobra.Command, args []string) error {
 	// Write to GitHub Actions step summary if available
 	summaryPath := os.Getenv("GITHUB_STEP_SUMMARY")
 	if summaryPath != "" {
-		file, err := os.OpenFile(summaryPath, os.O_APPEND|os.O_WRONLY, 0644)
-		if err != nil {
-			return fmt.Errorf("failed to open GitHub summary file: %w", err)
-		}
-		defer file.Close()
-
-		if _, err := file.WriteString(summaryContent); err != nil {
-			return fmt.Errorf("failed to write to GitHub summary: %w", err)
+		if err := ghactions.AppendToFile(summaryPath, summaryContent); err != nil {
+			return err
 		}
 	} else {
 		// If not in GitHub Actions, output to stdout
@@ -469,4 +1143,4 @@ func generateSummary(cmd *cobra.Command, args []string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
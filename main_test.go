@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// binPath is a home-ci-reporter binary built once for this package's tests,
+// so the concurrency test below drives the real CLI (and its flock-guarded
+// read-modify-write) rather than racing on the cobra package's global flag
+// variables by calling addStep directly from multiple goroutines.
+var binPath string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "home-ci-reporter-bin")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(dir)
+
+	binPath = filepath.Join(dir, "home-ci-reporter")
+	build := exec.Command("go", "build", "-o", binPath, ".")
+	if out, err := build.CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "building test binary: %v\n%s", err, out)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+// TestAddStepConcurrent spawns N goroutines that each run 100 `step`
+// invocations against the same report file in parallel, via separate CLI
+// processes racing for the same flock-protected lock file. It verifies no
+// update is lost and the report is always valid YAML afterward.
+func TestAddStepConcurrent(t *testing.T) {
+	const goroutines = 10
+	const stepsEach = 100
+
+	dir := t.TempDir()
+	reportPath := filepath.Join(dir, "report.yaml")
+
+	if out, err := exec.Command(binPath, "init", reportPath, "concurrency-test").CombinedOutput(); err != nil {
+		t.Fatalf("init: %v\n%s", err, out)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines*stepsEach)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < stepsEach; i++ {
+				phase := fmt.Sprintf("phase-%d-%d", g, i)
+				cmd := exec.Command(binPath, "step", phase, "passed", "ok", "-f", reportPath, "--timeout", "30s")
+				if out, err := cmd.CombinedOutput(); err != nil {
+					errs <- fmt.Errorf("step %s: %w\n%s", phase, err, out)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("read final report: %v", err)
+	}
+
+	var report TestReport
+	if err := yaml.Unmarshal(data, &report); err != nil {
+		t.Fatalf("final report is not valid YAML: %v\n%s", err, data)
+	}
+
+	if want := goroutines * stepsEach; len(report.Steps) != want {
+		t.Fatalf("got %d steps, want %d (lost updates under concurrent addStep)", len(report.Steps), want)
+	}
+}